@@ -2,12 +2,20 @@
 package opensearch_query
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	_ "embed"
+	"encoding/json"
 	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/opensearch-project/opensearch-go/v2"
+	opensearchaws "github.com/opensearch-project/opensearch-go/v2/signer/awsv2"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,20 +28,40 @@ import (
 //go:embed sample.conf
 var sampleConfig string
 
+// defaultCompositePageSize is the number of buckets requested per page of a
+// composite aggregation when composite_page_size is not set.
+const defaultCompositePageSize = 1000
+
+// queryPeriodExpr renders a QueryPeriod as an OpenSearch date-math offset in
+// a single unit, e.g. "600s". Duration.String() produces compound units
+// like "10m0s", which date math rejects.
+func queryPeriodExpr(period config.Duration) string {
+	return fmt.Sprintf("%ds", int64(time.Duration(period).Seconds()))
+}
+
 // OpensearchQuery struct
 type OpensearchQuery struct {
-	URLs                []string        `toml:"urls"`
-	Username            string          `toml:"username"`
-	Password            string          `toml:"password"`
-	EnableSniffer       bool            `toml:"enable_sniffer"`
-	Timeout             config.Duration `toml:"timeout"`
-	HealthCheckInterval config.Duration `toml:"health_check_interval"`
-	Aggregations        []osAggregation `toml:"aggregation"`
+	URLs                []string          `toml:"urls"`
+	Username            string            `toml:"username"`
+	Password            string            `toml:"password"`
+	EnableSniffer       bool              `toml:"enable_sniffer"`
+	Timeout             config.Duration   `toml:"timeout"`
+	HealthCheckInterval config.Duration   `toml:"health_check_interval"`
+	Aggregations        []osAggregation   `toml:"aggregation"`
+	AWS                 *AWSSigningConfig `toml:"aws"`
 
 	Log telegraf.Logger `toml:"-"`
 
 	influxtls.ClientConfig
+	// osClient is swapped out by the sniffer on pool rotation; always read
+	// it through client(), never access it directly, so concurrent search
+	// requests can't race the swap.
 	osClient *opensearch.Client
+
+	clientMu  sync.Mutex // guards osClient during pool rotation
+	done      chan struct{}
+	sniffWg   sync.WaitGroup
+	awsSigner opensearch.Signer // built once in Init, reused across pool rotations
 }
 
 // osAggregation struct
@@ -49,10 +77,25 @@ type osAggregation struct {
 	Tags                 []string        `toml:"tags"`
 	IncludeMissingTag    bool            `toml:"include_missing_tag"`
 	MissingTagValue      string          `toml:"missing_tag_value"`
+	Composite            bool            `toml:"composite"`
+	CompositePageSize    int             `toml:"composite_page_size"`
+	MaxBuckets           int             `toml:"max_buckets"`
 	mapMetricFields      map[string]string
 	aggregationQueryList []aggregationQueryData
 }
 
+// AWSSigningConfig configures SigV4 request signing for Amazon OpenSearch
+// Service or OpenSearch Serverless domains that authenticate via IAM
+// instead of basic auth.
+type AWSSigningConfig struct {
+	Region               string `toml:"region"`
+	Service              string `toml:"service"`
+	Profile              string `toml:"profile"`
+	RoleARN              string `toml:"role_arn"`
+	WebIdentityTokenFile string `toml:"web_identity_token_file"`
+	CredentialChain      bool   `toml:"credential_chain"`
+}
+
 func (*OpensearchQuery) SampleConfig() string {
 	return sampleConfig
 }
@@ -63,6 +106,30 @@ func (o *OpensearchQuery) Init() error {
 		return fmt.Errorf("opensearch urls is not defined")
 	}
 
+	if o.AWS != nil {
+		if o.Username != "" || o.Password != "" {
+			return fmt.Errorf("only one of basic auth or [inputs.opensearch_query.aws] may be configured")
+		}
+		if o.AWS.Service == "" {
+			o.AWS.Service = "es"
+		}
+		if o.AWS.Service != "es" && o.AWS.Service != "aoss" {
+			return fmt.Errorf("aws.service must be 'es' or 'aoss', got %q", o.AWS.Service)
+		}
+		if o.AWS.Region == "" {
+			return fmt.Errorf("aws.region is required when [inputs.opensearch_query.aws] is configured")
+		}
+		if o.AWS.WebIdentityTokenFile != "" && o.AWS.RoleARN == "" {
+			return fmt.Errorf("aws.web_identity_token_file requires aws.role_arn to be set")
+		}
+
+		signer, err := o.buildAWSSigner(context.Background())
+		if err != nil {
+			return fmt.Errorf("building aws signer: %w", err)
+		}
+		o.awsSigner = signer
+	}
+
 	err := o.connectToOpensearch()
 	if err != nil {
 		o.Log.Errorf("E! error connecting to opensearch: %s", err)
@@ -79,6 +146,9 @@ func (o *OpensearchQuery) Init() error {
 		if agg.DateField == "" {
 			return fmt.Errorf("field 'date_field' is not set")
 		}
+		if len(agg.MetricFields) > 0 && agg.MetricFunction == "" {
+			return fmt.Errorf("field 'metric_function' is not set")
+		}
 		err = o.initAggregation(ctx, agg, i)
 		if err != nil {
 			o.Log.Errorf("%s", err)
@@ -101,6 +171,10 @@ func (o *OpensearchQuery) initAggregation(ctx context.Context, agg osAggregation
 		}
 	}
 
+	if agg.Composite && agg.CompositePageSize <= 0 {
+		agg.CompositePageSize = defaultCompositePageSize
+	}
+
 	err = agg.buildAggregationQuery()
 	if err != nil {
 		return err
@@ -111,7 +185,23 @@ func (o *OpensearchQuery) initAggregation(ctx context.Context, agg osAggregation
 }
 
 func (o *OpensearchQuery) connectToOpensearch() error {
-	var client *opensearch.Client
+	client, err := opensearch.NewClient(o.clientConfig(o.URLs))
+	if err != nil {
+		return err
+	}
+
+	o.clientMu.Lock()
+	o.osClient = client
+	o.clientMu.Unlock()
+	return nil
+}
+
+// clientConfig builds the opensearch.Config used to (re)create the client,
+// pointed at addresses rather than the statically configured URLs so the
+// sniffer can rotate the pool without rebuilding TLS/auth/signing settings.
+// The AWS signer, if any, is built once in Init and reused here rather than
+// rebuilt on every rotation.
+func (o *OpensearchQuery) clientConfig(addresses []string) opensearch.Config {
 	var transport *http.Transport
 
 	if o.InsecureSkipVerify {
@@ -121,10 +211,10 @@ func (o *OpensearchQuery) connectToOpensearch() error {
 	}
 
 	clientConfig := opensearch.Config{
-		Addresses: o.URLs,
+		Addresses: addresses,
 		Username:  o.Username,
 		Password:  o.Password,
-		//Signer:                nil,
+		Signer:    o.awsSigner,
 		//CACert:                nil,
 	}
 
@@ -132,24 +222,237 @@ func (o *OpensearchQuery) connectToOpensearch() error {
 		clientConfig.Transport = transport
 	}
 
-	client, err := opensearch.NewClient(clientConfig)
+	return clientConfig
+}
+
+// buildAWSSigner constructs a SigV4-signing opensearch.Signer from the
+// configured [inputs.opensearch_query.aws] block. Credentials are cached
+// and refreshed on expiry rather than reloaded on every request.
+func (o *OpensearchQuery) buildAWSSigner(ctx context.Context) (opensearch.Signer, error) {
+	var optFns []func(*awsconfig.LoadOptions) error
+	if o.AWS.Region != "" {
+		optFns = append(optFns, awsconfig.WithRegion(o.AWS.Region))
+	}
+	if o.AWS.Profile != "" {
+		optFns = append(optFns, awsconfig.WithSharedConfigProfile(o.AWS.Profile))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading aws config: %w", err)
+	}
+
+	switch {
+	case o.AWS.RoleARN != "" && o.AWS.WebIdentityTokenFile != "":
+		cfg.Credentials = stscreds.NewWebIdentityRoleProvider(
+			sts.NewFromConfig(cfg), o.AWS.RoleARN, stscreds.IdentityTokenFile(o.AWS.WebIdentityTokenFile),
+		)
+	case o.AWS.RoleARN != "":
+		cfg.Credentials = stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), o.AWS.RoleARN)
+	case !o.AWS.CredentialChain && o.AWS.Profile == "":
+		return nil, fmt.Errorf("aws signing requires role_arn, profile, or credential_chain to be set")
+	}
+	cfg.Credentials = aws.NewCredentialsCache(cfg.Credentials)
+
+	return opensearchaws.NewSignerWithService(cfg, o.AWS.Service)
+}
+
+// client returns the currently active opensearch client, synchronized with
+// the sniffer's pool rotation.
+func (o *OpensearchQuery) client() *opensearch.Client {
+	o.clientMu.Lock()
+	defer o.clientMu.Unlock()
+	return o.osClient
+}
+
+// sniffLoop periodically refreshes the client's address pool from the
+// cluster's live nodes while EnableSniffer is set.
+func (o *OpensearchQuery) sniffLoop() {
+	defer o.sniffWg.Done()
+
+	ticker := time.NewTicker(time.Duration(o.HealthCheckInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.done:
+			return
+		case <-ticker.C:
+			if err := o.discoverNodes(); err != nil {
+				o.Log.Errorf("error discovering opensearch nodes: %s", err)
+			}
+		}
+	}
+}
+
+// nodesHTTPResponse models the subset of the _nodes/http response needed to
+// rebuild the client's address pool.
+type nodesHTTPResponse struct {
+	Nodes map[string]struct {
+		HTTP struct {
+			PublishAddress string `json:"publish_address"`
+		} `json:"http"`
+	} `json:"nodes"`
+}
+
+// discoverNodes queries _nodes/http on the current client, marks any
+// unreachable node as down, and rotates the pool to the healthy set.
+func (o *OpensearchQuery) discoverNodes() error {
+	req, err := http.NewRequest(http.MethodGet, "/_nodes/http", http.NoBody)
 	if err != nil {
 		return err
 	}
 
+	client := o.client()
+	if client == nil {
+		if err := o.connectToOpensearch(); err != nil {
+			return fmt.Errorf("error connecting to opensearch: %w", err)
+		}
+		client = o.client()
+	}
+
+	res, err := client.Transport.Perform(req)
+	if err != nil {
+		return fmt.Errorf("performing _nodes/http request: %w", err)
+	}
+	defer res.Body.Close()
+
+	var parsed nodesHTTPResponse
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return fmt.Errorf("decoding _nodes/http response: %w", err)
+	}
+
+	healthy := make([]string, 0, len(parsed.Nodes))
+	for id, node := range parsed.Nodes {
+		addr := node.HTTP.PublishAddress
+		if addr == "" {
+			continue
+		}
+		addr = o.normalizePublishAddress(addr)
+		if !o.isNodeReachable(addr) {
+			o.Log.Warnf("opensearch node %s (%s) is unreachable, removing from pool", id, addr)
+			continue
+		}
+		healthy = append(healthy, addr)
+	}
+
+	if len(healthy) == 0 {
+		return fmt.Errorf("no healthy opensearch nodes discovered, keeping existing pool")
+	}
+
+	return o.rotatePool(healthy)
+}
+
+// nodeScheme returns the scheme to use for a bare host:port publish_address,
+// derived from the configured seed URLs (falling back to the TLS config) so
+// discovered nodes are probed and dialed the same way the cluster is
+// configured.
+func (o *OpensearchQuery) nodeScheme() string {
+	for _, u := range o.URLs {
+		switch {
+		case strings.HasPrefix(u, "https://"):
+			return "https"
+		case strings.HasPrefix(u, "http://"):
+			return "http"
+		}
+	}
+	if o.InsecureSkipVerify || o.TLSCert != "" || o.TLSCA != "" {
+		return "https"
+	}
+	return "http"
+}
+
+// normalizePublishAddress converts an OpenSearch _nodes/http
+// publish_address, which may be a bare "host:port" or a "name/ip:port"
+// pair, into a fully qualified URL using the cluster's scheme.
+func (o *OpensearchQuery) normalizePublishAddress(addr string) string {
+	if strings.Contains(addr, "://") {
+		return addr
+	}
+	if idx := strings.LastIndex(addr, "/"); idx != -1 {
+		addr = addr[idx+1:]
+	}
+	return o.nodeScheme() + "://" + addr
+}
+
+// isNodeReachable does a lightweight health check against a discovered node
+// using the same TLS and (if configured) AWS SigV4 signing settings as the
+// main client, by probing through a client scoped to just that address, so
+// TLS clusters and IAM-authenticated domains are health-checked correctly.
+func (o *OpensearchQuery) isNodeReachable(addr string) bool {
+	probe, err := opensearch.NewClient(o.clientConfig([]string{addr}))
+	if err != nil {
+		return false
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/", http.NoBody)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
+	defer cancel()
+
+	res, err := probe.Transport.Perform(req.WithContext(ctx))
+	if err != nil {
+		return false
+	}
+	defer res.Body.Close()
+	return res.StatusCode < http.StatusInternalServerError
+}
+
+// rotatePool swaps in a client built against the newly discovered, healthy
+// addresses.
+func (o *OpensearchQuery) rotatePool(addresses []string) error {
+	client, err := opensearch.NewClient(o.clientConfig(addresses))
+	if err != nil {
+		return err
+	}
+
+	o.clientMu.Lock()
 	o.osClient = client
+	o.clientMu.Unlock()
+	return nil
+}
+
+// Start begins the background node-discovery loop configured via
+// enable_sniffer. It runs regardless of whether the initial connect in
+// Init succeeded, so a cluster that's still down at startup still gets
+// sniffed once it comes up. This (plus Stop) makes OpensearchQuery a
+// telegraf.ServiceInput, since Stop is only ever called on one of those.
+func (o *OpensearchQuery) Start(_ telegraf.Accumulator) error {
+	if !o.EnableSniffer {
+		return nil
+	}
+
+	o.done = make(chan struct{})
+	o.sniffWg.Add(1)
+	go o.sniffLoop()
 	return nil
 }
 
+// Stop halts the background node-discovery loop started in Start.
+func (o *OpensearchQuery) Stop() {
+	if o.done == nil {
+		return
+	}
+	close(o.done)
+	o.sniffWg.Wait()
+}
+
 // Gather writes the results of the queries from OpenSearch to the Accumulator.
 func (o *OpensearchQuery) Gather(acc telegraf.Accumulator) error {
-	var wg sync.WaitGroup
-
-	err := o.connectToOpensearch()
-	if err != nil {
-		return err
+	// Init logs and tolerates a failed initial connection so telegraf can
+	// still start against a cluster that isn't up yet; reconnect here
+	// rather than leaving osClient nil for the lifetime of the plugin.
+	if o.client() == nil {
+		if err := o.connectToOpensearch(); err != nil {
+			return fmt.Errorf("error connecting to opensearch: %w", err)
+		}
 	}
 
+	var wg sync.WaitGroup
+
 	for i, agg := range o.Aggregations {
 		wg.Add(1)
 		go func(agg osAggregation, i int) {
@@ -195,6 +498,10 @@ func (o *OpensearchQuery) osAggregationQuery(acc telegraf.Accumulator, aggregati
 		aggregation = o.Aggregations[i]
 	}
 
+	if aggregation.Composite {
+		return o.compositeAggregationQuery(ctx, acc, aggregation)
+	}
+
 	searchResult, err := o.runAggregationQuery(ctx, aggregation)
 	if err != nil {
 		return err
@@ -208,6 +515,201 @@ func (o *OpensearchQuery) osAggregationQuery(acc telegraf.Accumulator, aggregati
 	return parseAggregationResult(acc, aggregation.aggregationQueryList, searchResult)
 }
 
+// compositeAggregationQuery pages through a composite aggregation over
+// aggregation.Tags, following the after_key cursor until OpenSearch returns
+// no further buckets, streaming each page into acc as it arrives. It exists
+// because the default terms aggregation silently truncates high-cardinality
+// tag combinations at the configured bucket size.
+func (o *OpensearchQuery) compositeAggregationQuery(ctx context.Context, acc telegraf.Accumulator, aggregation osAggregation) error {
+	pageSize := aggregation.CompositePageSize
+	if pageSize <= 0 {
+		pageSize = defaultCompositePageSize
+	}
+
+	var afterKey map[string]interface{}
+	var seen int
+
+	for {
+		query := aggregation.buildCompositeAggregationQuery(pageSize, afterKey)
+
+		res, err := o.runRawAggregationQuery(ctx, aggregation.Index, query)
+		if err != nil {
+			return err
+		}
+
+		buckets, nextKey, err := parseCompositeAggregationPage(aggregation, res)
+		if err != nil {
+			return err
+		}
+
+		// Enforce max_buckets before emitting so a page can't push the
+		// accumulator past the cap; truncate and stop on the page that
+		// would exceed it instead of emitting it whole first.
+		if aggregation.MaxBuckets > 0 && seen+len(buckets) > aggregation.MaxBuckets {
+			if remaining := aggregation.MaxBuckets - seen; remaining > 0 {
+				emitCompositeBuckets(acc, aggregation, buckets[:remaining])
+			}
+			acc.AddError(fmt.Errorf("opensearch composite aggregation %q exceeded max_buckets (%d)", aggregation.MeasurementName, aggregation.MaxBuckets))
+			return nil
+		}
+
+		emitCompositeBuckets(acc, aggregation, buckets)
+		seen += len(buckets)
+
+		if nextKey == nil {
+			return nil
+		}
+		afterKey = nextKey
+	}
+}
+
+// buildCompositeAggregationQuery builds a composite aggregation query over
+// the configured Tags, honoring FilterQuery, DateFieldFormat and
+// IncludeMissingTag the same way the terms path does, and computing
+// MetricFunction over each MetricFields entry as a per-bucket
+// sub-aggregation, optionally resuming from a previous page's after_key.
+func (a *osAggregation) buildCompositeAggregationQuery(pageSize int, afterKey map[string]interface{}) map[string]interface{} {
+	sources := make([]map[string]interface{}, 0, len(a.Tags))
+	for _, tag := range a.Tags {
+		source := map[string]interface{}{
+			"terms": map[string]interface{}{"field": tag},
+		}
+		if a.IncludeMissingTag {
+			source["missing_bucket"] = true
+		}
+		sources = append(sources, map[string]interface{}{tag: source})
+	}
+
+	composite := map[string]interface{}{
+		"size":    pageSize,
+		"sources": sources,
+	}
+	if afterKey != nil {
+		composite["after"] = afterKey
+	}
+
+	compositeAgg := map[string]interface{}{"composite": composite}
+	if len(a.MetricFields) > 0 {
+		metricAggs := make(map[string]interface{}, len(a.MetricFields))
+		for _, field := range a.MetricFields {
+			metricAggs[field] = map[string]interface{}{
+				a.MetricFunction: map[string]interface{}{"field": field},
+			}
+		}
+		compositeAgg["aggs"] = metricAggs
+	}
+
+	dateRange := map[string]interface{}{"gte": "now-" + queryPeriodExpr(a.QueryPeriod)}
+	if a.DateFieldFormat != "" {
+		dateRange["format"] = a.DateFieldFormat
+	}
+
+	filters := []map[string]interface{}{
+		{"range": map[string]interface{}{a.DateField: dateRange}},
+	}
+	if a.FilterQuery != "" {
+		filters = append(filters, map[string]interface{}{
+			"query_string": map[string]interface{}{"query": a.FilterQuery},
+		})
+	}
+
+	return map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filters},
+		},
+		"aggs": map[string]interface{}{
+			"composite_agg": compositeAgg,
+		},
+	}
+}
+
+// runRawAggregationQuery sends a raw aggregation query body to OpenSearch
+// and decodes the JSON response, bypassing the typed search helpers used by
+// the non-composite path since composite responses carry an after_key.
+func (o *OpensearchQuery) runRawAggregationQuery(ctx context.Context, index string, query map[string]interface{}) (map[string]interface{}, error) {
+	payload, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	client := o.client()
+	res, err := client.Search(
+		client.Search.WithContext(ctx),
+		client.Search.WithIndex(index),
+		client.Search.WithBody(bytes.NewReader(payload)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("opensearch search request failed: %s", res.String())
+	}
+
+	var parsed map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// parseCompositeAggregationPage extracts the raw buckets and after_key from
+// a composite aggregation page without emitting anything, so the caller can
+// enforce max_buckets before any bucket is written to the accumulator.
+func parseCompositeAggregationPage(aggregation osAggregation, res map[string]interface{}) ([]interface{}, map[string]interface{}, error) {
+	aggs, ok := res["aggregations"].(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("opensearch composite aggregation %q: unexpected response", aggregation.MeasurementName)
+	}
+	compositeAgg, ok := aggs["composite_agg"].(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("opensearch composite aggregation %q: unexpected response", aggregation.MeasurementName)
+	}
+
+	buckets, _ := compositeAgg["buckets"].([]interface{})
+	if len(buckets) == 0 {
+		return nil, nil, nil
+	}
+
+	nextKey, _ := compositeAgg["after_key"].(map[string]interface{})
+	return buckets, nextKey, nil
+}
+
+// emitCompositeBuckets writes one field set per composite aggregation
+// bucket, including any configured per-bucket metric values.
+func emitCompositeBuckets(acc telegraf.Accumulator, aggregation osAggregation, buckets []interface{}) {
+	for _, b := range buckets {
+		bucket, ok := b.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _ := bucket["key"].(map[string]interface{})
+		tags := make(map[string]string, len(key))
+		for k, v := range key {
+			if v == nil {
+				tags[k] = aggregation.MissingTagValue
+				continue
+			}
+			tags[k] = fmt.Sprintf("%v", v)
+		}
+
+		fields := map[string]interface{}{"doc_count": bucket["doc_count"]}
+		for _, field := range aggregation.MetricFields {
+			metricAgg, ok := bucket[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if value, ok := metricAgg["value"]; ok {
+				fields[field] = value
+			}
+		}
+
+		acc.AddFields(aggregation.MeasurementName, fields, tags)
+	}
+}
+
 func init() {
 	inputs.Add("opensearch_query", func() telegraf.Input {
 		return &OpensearchQuery{